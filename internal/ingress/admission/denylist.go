@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package admission implements the validation checks the ingress-nginx
+// admission webhook runs against an Ingress before it is persisted, so
+// that conflicting or out-of-policy annotation values are rejected at
+// `kubectl apply` time instead of silently producing a broken or
+// unreachable configuration.
+package admission
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ipdenylist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const whitelistSourceRangeAnnotation = "whitelist-source-range"
+
+// whitelistAnnotationFields is just enough annotation registration to
+// read whitelist-source-range's raw value through
+// parser.GetStringAnnotation; the annotation itself is owned and
+// registered by the ipwhitelist package.
+var whitelistAnnotationFields = parser.AnnotationFields{
+	whitelistSourceRangeAnnotation: {
+		Validator: parser.ValidateCIDRs,
+		Scope:     parser.AnnotationScopeLocation,
+		Risk:      parser.AnnotationRiskMedium,
+	},
+}
+
+// ValidateDenylist is meant to be called by the admission webhook's HTTP
+// handler for every Ingress it reviews, alongside the handler's other
+// per-annotation validators; that HTTP handler lives in the webhook
+// binary's own package and isn't part of this checkout. It rejects the
+// Ingress if its denylist-source-range
+// overlaps with whitelist-source-range on the same location, or falls
+// outside allowedSupernets, the operator-configured superset of networks
+// a denylist-source-range is permitted to target (e.g. "0.0.0.0/0" minus
+// RFC1918). allowedSupernets may be empty to skip that check.
+//
+// The returned warnings are non-fatal diagnostics from collapsing the
+// denylist's CIDRs (e.g. redundant or mergeable entries); the webhook is
+// expected to copy them into the AdmissionResponse's Warnings field so
+// they reach `kubectl apply` output instead of only the controller logs.
+func ValidateDenylist(ing *networking.Ingress, r resolver.Resolver, allowedSupernets []string) ([]string, error) {
+	parsed, err := ipdenylist.NewParser(r).Parse(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceRange, ok := parsed.(*ipdenylist.SourceRange)
+	if !ok {
+		return nil, nil
+	}
+
+	if err := sourceRange.ValidateAgainst(whitelistCIDRs(ing), allowedSupernets); err != nil {
+		return nil, err
+	}
+
+	return sourceRange.Warnings, nil
+}
+
+// whitelistCIDRs reads the raw whitelist-source-range annotation value,
+// returning nil if it isn't set.
+func whitelistCIDRs(ing *networking.Ingress) []string {
+	val, err := parser.GetStringAnnotation(whitelistSourceRangeAnnotation, ing, whitelistAnnotationFields)
+	if err != nil {
+		return nil
+	}
+
+	cidrs := make([]string, 0)
+	for _, v := range strings.Split(val, ",") {
+		cidrs = append(cidrs, strings.TrimSpace(v))
+	}
+
+	return cidrs
+}
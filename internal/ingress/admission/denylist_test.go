@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *networking.Ingress {
+	defaultBackend := networking.IngressBackend{
+		Service: &networking.IngressServiceBackend{
+			Name: "default-backend",
+			Port: networking.ServiceBackendPort{
+				Number: 80,
+			},
+		},
+	}
+
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			DefaultBackend: &networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{
+					Name: "default-backend",
+					Port: networking.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+			Rules: []networking.IngressRule{
+				{
+					Host: "foo.bar.com",
+					IngressRuleValue: networking.IngressRuleValue{
+						HTTP: &networking.HTTPIngressRuleValue{
+							Paths: []networking.HTTPIngressPath{
+								{
+									Path:    "/foo",
+									Backend: defaultBackend,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateDenylistWithoutAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	if _, err := ValidateDenylist(ing, &resolver.Mock{}, nil); err != nil {
+		t.Errorf("unexpected error for an Ingress without denylist annotations: %v", err)
+	}
+}
+
+func TestValidateDenylistOverlapsWhitelist(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("denylist-source-range")] = "10.0.0.0/8"
+	data[parser.GetAnnotationWithPrefix("whitelist-source-range")] = "10.1.0.0/16"
+	ing.SetAnnotations(data)
+
+	if _, err := ValidateDenylist(ing, &resolver.Mock{}, nil); err == nil {
+		t.Error("expected an error for a denylist overlapping the whitelist")
+	}
+}
+
+func TestValidateDenylistOutsideAllowedSupernets(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("denylist-source-range")] = "8.8.8.8/32"
+	ing.SetAnnotations(data)
+
+	if _, err := ValidateDenylist(ing, &resolver.Mock{}, []string{"10.0.0.0/8"}); err == nil {
+		t.Error("expected an error for a denylist CIDR outside the allowed supernets")
+	}
+}
+
+func TestValidateDenylistWithinPolicy(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("denylist-source-range")] = "10.0.0.0/8"
+	data[parser.GetAnnotationWithPrefix("whitelist-source-range")] = "192.168.0.0/16"
+	ing.SetAnnotations(data)
+
+	if _, err := ValidateDenylist(ing, &resolver.Mock{}, []string{"0.0.0.0/0"}); err != nil {
+		t.Errorf("unexpected error for a compliant, non-overlapping denylist: %v", err)
+	}
+}
+
+func TestValidateDenylistReturnsCollapseWarnings(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("denylist-source-range")] = "10.0.0.0/9,10.128.0.0/9"
+	ing.SetAnnotations(data)
+
+	warnings, err := ValidateDenylist(ing, &resolver.Mock{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) == 0 {
+		t.Error("expected a warning about the two /9s collapsing to a single /8")
+	}
+}
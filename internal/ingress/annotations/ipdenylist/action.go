@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Action is the response a matched denylist rule should produce.
+type Action string
+
+const (
+	// ActionDeny returns a hard 403, the long-standing default behavior.
+	ActionDeny Action = "deny"
+	// ActionTarpit holds the connection open and returns a slow 444,
+	// wasting the client's time instead of confirming the block quickly.
+	ActionTarpit Action = "tarpit"
+	// ActionRateLimit applies a per-IP limit_req_zone instead of
+	// rejecting outright, for rolling out a new rule cautiously.
+	ActionRateLimit Action = "ratelimit"
+	// ActionLogOnly matches the rule and logs it but otherwise lets the
+	// request through, for dry-running a rule before it blocks traffic.
+	ActionLogOnly Action = "log-only"
+)
+
+const ratelimitActionPrefix = "ratelimit:"
+
+// validateDenylistAction checks that the annotation is "deny", "tarpit",
+// "log-only", or "ratelimit:<rps>" with a positive numeric rps.
+func validateDenylistAction(val string) error {
+	_, _, err := parseDenylistAction(val)
+	return err
+}
+
+// parseDenylistAction parses the denylist-action annotation value into
+// an Action and, for ActionRateLimit, the requests-per-second limit.
+func parseDenylistAction(val string) (Action, float64, error) {
+	switch Action(val) {
+	case ActionDeny, ActionTarpit, ActionLogOnly:
+		return Action(val), 0, nil
+	}
+
+	if !strings.HasPrefix(val, ratelimitActionPrefix) {
+		return "", 0, fmt.Errorf("invalid denylist-action %q, must be one of deny, tarpit, log-only or ratelimit:<rps>", val)
+	}
+
+	rps, err := strconv.ParseFloat(strings.TrimPrefix(val, ratelimitActionPrefix), 64)
+	if err != nil || !(rps > 0) {
+		return "", 0, fmt.Errorf("invalid denylist-action %q, ratelimit must be followed by a positive rps", val)
+	}
+
+	return ActionRateLimit, rps, nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import "testing"
+
+func TestParseDenylistAction(t *testing.T) {
+	tests := []struct {
+		val        string
+		wantAction Action
+		wantRPS    float64
+		wantErr    bool
+	}{
+		{"deny", ActionDeny, 0, false},
+		{"tarpit", ActionTarpit, 0, false},
+		{"log-only", ActionLogOnly, 0, false},
+		{"ratelimit:5", ActionRateLimit, 5, false},
+		{"ratelimit:0.5", ActionRateLimit, 0.5, false},
+		{"ratelimit:0", "", 0, true},
+		{"ratelimit:-1", "", 0, true},
+		{"ratelimit:nope", "", 0, true},
+		{"block", "", 0, true},
+		{"", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		action, rps, err := parseDenylistAction(tt.val)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDenylistAction(%q): got err=%v, wantErr=%v", tt.val, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if action != tt.wantAction || rps != tt.wantRPS {
+			t.Errorf("parseDenylistAction(%q) = (%q, %v), want (%q, %v)", tt.val, action, rps, tt.wantAction, tt.wantRPS)
+		}
+	}
+}
+
+func TestValidateDenylistAction(t *testing.T) {
+	if err := validateDenylistAction("deny"); err != nil {
+		t.Errorf("unexpected error for a valid action: %v", err)
+	}
+	if err := validateDenylistAction("bogus"); err == nil {
+		t.Error("expected an error for an invalid action")
+	}
+}
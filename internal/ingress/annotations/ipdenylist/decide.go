@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import "net"
+
+// Decision is the outcome of evaluating a request's source IP against a
+// SourceRange: whether it matched at all, and if so, the Action to take.
+type Decision struct {
+	// Matched is true if ip matched the SourceRange's CIDRs, countries,
+	// or ASNs.
+	Matched bool
+	// Action is the response to produce for a matched request. It's the
+	// zero Action ("") when Matched is false.
+	Action Action
+	// RateLimitRPS is the requests-per-second limit to apply when Action
+	// is ActionRateLimit, and is meaningless otherwise.
+	RateLimitRPS float64
+}
+
+// Decide evaluates ip against sr's CIDRs, countries and ASNs and reports
+// what should happen to the request. It's the single place that turns a
+// parsed SourceRange into a concrete verdict, so that callers making
+// blocking decisions and callers merely inspecting a rule agree on what
+// "matched" means.
+//
+// Decide itself only runs in Go, for whichever caller passes it an IP;
+// Parse does not call it, and it is not invoked per-request in the nginx
+// data path. Making Action actually
+// take effect on live traffic requires the controller's nginx.tmpl to
+// emit, per Action, an `if`/deny for ActionDeny, a `return 444` for
+// ActionTarpit, a `limit_req_zone`/`limit_req` pair sized from
+// RateLimitRPS for ActionRateLimit, or nothing but a log line for
+// ActionLogOnly — that template isn't part of this checkout, so Decide
+// is the seam that template would call into, not a replacement for it.
+func Decide(sr *SourceRange, ip net.IP) Decision {
+	if sr == nil || ip == nil {
+		return Decision{}
+	}
+
+	if !matchesCIDR(sr, ip) && !sr.MatchesGeo(ip) {
+		return Decision{}
+	}
+
+	action := sr.Action
+	if action == "" {
+		action = ActionDeny
+	}
+
+	return Decision{
+		Matched:      true,
+		Action:       action,
+		RateLimitRPS: sr.RateLimitRPS,
+	}
+}
+
+// matchesCIDR reports whether ip falls inside any of sr's CIDRs.
+func matchesCIDR(sr *SourceRange, ip net.IP) bool {
+	for _, c := range sr.CIDR {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			single := net.ParseIP(c)
+			if single == nil {
+				continue
+			}
+			if ip4 := single.To4(); ip4 != nil {
+				n = &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+			} else {
+				n = &net.IPNet{IP: single, Mask: net.CIDRMask(128, 128)}
+			}
+		}
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
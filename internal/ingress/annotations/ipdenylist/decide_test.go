@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDecideNoMatch(t *testing.T) {
+	sr := &SourceRange{CIDR: []string{"10.0.0.0/8"}}
+
+	d := Decide(sr, net.ParseIP("8.8.8.8"))
+	if d.Matched {
+		t.Errorf("expected no match, got %+v", d)
+	}
+}
+
+func TestDecideCIDRMatchDefaultsToDeny(t *testing.T) {
+	sr := &SourceRange{CIDR: []string{"10.0.0.0/8"}}
+
+	d := Decide(sr, net.ParseIP("10.1.2.3"))
+	if !d.Matched || d.Action != ActionDeny {
+		t.Errorf("expected a deny match, got %+v", d)
+	}
+}
+
+func TestDecideCIDRMatchSingleIP(t *testing.T) {
+	sr := &SourceRange{CIDR: []string{"203.0.113.9"}}
+
+	d := Decide(sr, net.ParseIP("203.0.113.9"))
+	if !d.Matched || d.Action != ActionDeny {
+		t.Errorf("expected a deny match against a single-IP entry, got %+v", d)
+	}
+}
+
+func TestDecideCIDRMatchCarriesAction(t *testing.T) {
+	sr := &SourceRange{CIDR: []string{"10.0.0.0/8"}, Action: ActionRateLimit, RateLimitRPS: 5}
+
+	d := Decide(sr, net.ParseIP("10.1.2.3"))
+	if !d.Matched || d.Action != ActionRateLimit || d.RateLimitRPS != 5 {
+		t.Errorf("expected a ratelimit match at 5rps, got %+v", d)
+	}
+}
+
+func TestDecideGeoMatch(t *testing.T) {
+	withGeoIPDB(t, fakeGeoIPLookuper{
+		"203.0.113.9": newTestRecord("CN", 4134),
+	})
+
+	sr := &SourceRange{Countries: []string{"CN"}, Action: ActionTarpit}
+
+	d := Decide(sr, net.ParseIP("203.0.113.9"))
+	if !d.Matched || d.Action != ActionTarpit {
+		t.Errorf("expected a tarpit match on country, got %+v", d)
+	}
+}
+
+func TestDecideNilSourceRange(t *testing.T) {
+	if d := Decide(nil, net.ParseIP("10.0.0.1")); d.Matched {
+		t.Errorf("expected no match for a nil SourceRange, got %+v", d)
+	}
+}
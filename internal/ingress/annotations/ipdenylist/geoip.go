@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// countryCodeRegex matches a single ISO-3166-1 alpha-2 country code, e.g.
+// "US" or "DE".
+var countryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// asnRegex matches a single autonomous system number, with or without the
+// conventional "AS" prefix in either case, e.g. "AS64512" or "64512".
+var asnRegex = regexp.MustCompile(`(?i)^(?:AS)?\d+$`)
+
+// geoIPRecord mirrors the subset of the MaxMind GeoIP2/GeoLite2
+// Country+ASN schema this package needs.
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	AutonomousSystemNumber uint32 `maxminddb:"autonomous_system_number"`
+}
+
+// geoIPLookuper is the subset of *maxminddb.Reader this package relies
+// on. Tests substitute a fake implementation so SourceRange.MatchesGeo
+// can be exercised without a real mmdb file.
+type geoIPLookuper interface {
+	Lookup(ip net.IP, result interface{}) error
+}
+
+// geoIPDB is the process-wide GeoIP2 reader, opened once at controller
+// start-up via SetGeoIPDatabasePath and used by SourceRange.MatchesGeo.
+var geoIPDB struct {
+	sync.RWMutex
+	reader geoIPLookuper
+}
+
+// SetGeoIPDatabasePath opens the GeoIP2 (or GeoLite2) Country+ASN mmdb at
+// path and makes it available to SourceRange.MatchesGeo. It is expected
+// to be called once, during controller start-up, from wherever the
+// controller reads its other file-based configuration (flags/ConfigMap);
+// calling it again replaces and closes the previously loaded database.
+//
+// MatchesGeo (and Decide, which uses it) resolve country/ASN membership
+// in Go, for whichever caller passes them an IP; Parse does not call
+// them, and they are not an nginx geoip2/map
+// directive evaluated per-request in the data path. Emitting that
+// nginx-side directive is out of scope here: it belongs in the
+// controller's nginx.tmpl, which isn't part of this package.
+func SetGeoIPDatabasePath(path string) error {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening GeoIP2 database %q: %w", path, err)
+	}
+
+	geoIPDB.Lock()
+	old := geoIPDB.reader
+	geoIPDB.reader = reader
+	geoIPDB.Unlock()
+
+	if closer, ok := old.(io.Closer); ok {
+		closer.Close()
+	}
+
+	return nil
+}
+
+// lookupGeoIP resolves ip's country and autonomous system number using
+// the configured GeoIP2 database. ok is false if no database has been
+// loaded, or if ip isn't present in it.
+func lookupGeoIP(ip net.IP) (country string, asn uint32, ok bool) {
+	geoIPDB.RLock()
+	reader := geoIPDB.reader
+	geoIPDB.RUnlock()
+
+	if reader == nil {
+		return "", 0, false
+	}
+
+	var record geoIPRecord
+	if err := reader.Lookup(ip, &record); err != nil {
+		return "", 0, false
+	}
+
+	return record.Country.ISOCode, record.AutonomousSystemNumber, true
+}
+
+// MatchesGeo reports whether ip's GeoIP2-resolved country or autonomous
+// system number is one of this SourceRange's Countries or ASNs. It
+// returns false whenever there's nothing to compare: no country/ASN
+// rules configured, no GeoIP2 database loaded, or ip not found in it.
+func (sr *SourceRange) MatchesGeo(ip net.IP) bool {
+	if sr == nil || (len(sr.Countries) == 0 && len(sr.ASNs) == 0) {
+		return false
+	}
+
+	country, asn, ok := lookupGeoIP(ip)
+	if !ok {
+		return false
+	}
+
+	for _, c := range sr.Countries {
+		if c == country {
+			return true
+		}
+	}
+	for _, a := range sr.ASNs {
+		if a == asn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateCountryCodes checks that the annotation is a comma-separated
+// list of ISO-3166-1 alpha-2 country codes.
+func validateCountryCodes(val string) error {
+	for _, code := range strings.Split(val, ",") {
+		code = strings.TrimSpace(code)
+		if !countryCodeRegex.MatchString(code) {
+			return fmt.Errorf("invalid ISO-3166-1 country code %q", code)
+		}
+	}
+	return nil
+}
+
+// validateASNs checks that the annotation is a comma-separated list of
+// autonomous system numbers, optionally "AS"-prefixed.
+func validateASNs(val string) error {
+	for _, asn := range strings.Split(val, ",") {
+		if _, err := parseASN(asn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseASN converts a single ASN annotation value, with an optional "AS"
+// prefix, to its numeric form.
+func parseASN(val string) (uint32, error) {
+	val = strings.TrimSpace(val)
+	if !asnRegex.MatchString(val) {
+		return 0, fmt.Errorf("invalid autonomous system number %q", val)
+	}
+
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(val), "AS"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid autonomous system number %q: %w", val, err)
+	}
+
+	return uint32(n), nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+// fakeGeoIPLookuper is a geoIPLookuper backed by a plain map, so
+// SourceRange.MatchesGeo can be tested without a real mmdb file.
+type fakeGeoIPLookuper map[string]geoIPRecord
+
+func (f fakeGeoIPLookuper) Lookup(ip net.IP, result interface{}) error {
+	record, ok := f[ip.String()]
+	if !ok {
+		return fmt.Errorf("no record for %s", ip)
+	}
+	*result.(*geoIPRecord) = record
+	return nil
+}
+
+// withGeoIPDB installs reader for the duration of a test and restores
+// whatever was configured before.
+func withGeoIPDB(t *testing.T, reader geoIPLookuper) {
+	t.Helper()
+	geoIPDB.Lock()
+	old := geoIPDB.reader
+	geoIPDB.reader = reader
+	geoIPDB.Unlock()
+
+	t.Cleanup(func() {
+		geoIPDB.Lock()
+		geoIPDB.reader = old
+		geoIPDB.Unlock()
+	})
+}
+
+func newTestRecord(country string, asn uint32) geoIPRecord {
+	r := geoIPRecord{AutonomousSystemNumber: asn}
+	r.Country.ISOCode = country
+	return r
+}
+
+func TestSourceRangeMatchesGeoByCountry(t *testing.T) {
+	withGeoIPDB(t, fakeGeoIPLookuper{
+		"203.0.113.9": newTestRecord("CN", 4134),
+	})
+
+	sr := &SourceRange{Countries: []string{"CN", "RU"}}
+	if !sr.MatchesGeo(net.ParseIP("203.0.113.9")) {
+		t.Error("expected a match on country CN")
+	}
+}
+
+func TestSourceRangeMatchesGeoByASN(t *testing.T) {
+	withGeoIPDB(t, fakeGeoIPLookuper{
+		"203.0.113.9": newTestRecord("US", 64512),
+	})
+
+	sr := &SourceRange{ASNs: []uint32{64512}}
+	if !sr.MatchesGeo(net.ParseIP("203.0.113.9")) {
+		t.Error("expected a match on ASN 64512")
+	}
+}
+
+func TestSourceRangeMatchesGeoNoMatch(t *testing.T) {
+	withGeoIPDB(t, fakeGeoIPLookuper{
+		"203.0.113.9": newTestRecord("US", 64512),
+	})
+
+	sr := &SourceRange{Countries: []string{"CN"}, ASNs: []uint32{4134}}
+	if sr.MatchesGeo(net.ParseIP("203.0.113.9")) {
+		t.Error("expected no match for an unrelated country/ASN")
+	}
+}
+
+func TestSourceRangeMatchesGeoWithoutDatabase(t *testing.T) {
+	withGeoIPDB(t, nil)
+
+	sr := &SourceRange{Countries: []string{"CN"}}
+	if sr.MatchesGeo(net.ParseIP("203.0.113.9")) {
+		t.Error("expected no match when no GeoIP2 database is configured")
+	}
+}
+
+func TestSourceRangeMatchesGeoWithoutRules(t *testing.T) {
+	withGeoIPDB(t, fakeGeoIPLookuper{
+		"203.0.113.9": newTestRecord("CN", 4134),
+	})
+
+	sr := &SourceRange{}
+	if sr.MatchesGeo(net.ParseIP("203.0.113.9")) {
+		t.Error("expected no match when the SourceRange has no country/ASN rules")
+	}
+}
+
+func TestValidateCountryCodes(t *testing.T) {
+	tests := []struct {
+		val     string
+		wantErr bool
+	}{
+		{"US", false},
+		{"US,DE", false},
+		{" US , DE ", false},
+		{"us", true},
+		{"USA", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		err := validateCountryCodes(tt.val)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateCountryCodes(%q): got err=%v, wantErr=%v", tt.val, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseASN(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    uint32
+		wantErr bool
+	}{
+		{"64512", 64512, false},
+		{"AS64512", 64512, false},
+		{"as64512", 64512, false},
+		{"AS-1", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseASN(tt.val)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseASN(%q): got err=%v, wantErr=%v", tt.val, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("parseASN(%q) = %d, want %d", tt.val, got, tt.want)
+		}
+	}
+}
@@ -18,22 +18,34 @@ package ipdenylist
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/ingress-nginx/internal/net"
+	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/denylist/feeds"
 	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/pkg/util/sets"
 )
 
 const (
-	ipDenylistAnnotation = "denylist-source-range"
+	ipDenylistAnnotation            = "denylist-source-range"
+	denylistSourceFeedAnnotation    = "denylist-source-feed"
+	denylistSourceCountryAnnotation = "denylist-source-country"
+	denylistSourceASNAnnotation     = "denylist-source-asn"
+	denylistActionAnnotation        = "denylist-action"
 )
 
+// feedNameRegex matches a single named feed, e.g. "threat-intel" or
+// "tor-exit-nodes".
+var feedNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
 var denylistAnnotations = parser.Annotation{
 	Group: "acl",
 	Annotations: parser.AnnotationFields{
@@ -43,12 +55,87 @@ var denylistAnnotations = parser.Annotation{
 			Risk:          parser.AnnotationRiskMedium, // Failure on parsing this may cause undesired access
 			Documentation: `This annotation allows setting a list of IPs and networks that should be blocked to access this Location`,
 		},
+		denylistSourceFeedAnnotation: {
+			Validator:     validateFeedNames,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium, // Same trust level as denylist-source-range, it also gates access
+			Documentation: `This annotation allows blocking clients using one or more named, dynamically updated threat-intel feeds (e.g. "threat-intel,tor-exit-nodes"), in addition to denylist-source-range`,
+		},
+		denylistSourceCountryAnnotation: {
+			Validator:     validateCountryCodes,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium, // Same trust level as denylist-source-range, it also gates access
+			Documentation: `This annotation allows blocking clients by ISO-3166-1 country code (e.g. "CN,RU"), resolved through the controller's GeoIP2 database`,
+		},
+		denylistSourceASNAnnotation: {
+			Validator:     validateASNs,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium, // Same trust level as denylist-source-range, it also gates access
+			Documentation: `This annotation allows blocking clients by autonomous system number (e.g. "AS64512,AS64513"), resolved through the controller's GeoIP2 database`,
+		},
+		denylistActionAnnotation: {
+			Validator:     validateDenylistAction,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium, // Downgrading a block is as risky as setting one up in the first place
+			Documentation: `This annotation controls what happens when a client matches the denylist: "deny" (default) returns 403, "tarpit" holds the connection and returns a slow 444, "ratelimit:<rps>" applies a per-IP rate limit instead of blocking, and "log-only" matches and logs the request without blocking it`,
+		},
 	},
 }
 
-// SourceRange returns the CIDR
+// feedManager, when set via SetFeedManager, supplies the CIDRs currently
+// published by the feeds referenced through denylist-source-feed. It is
+// populated once at controller start-up and is safe for concurrent use.
+var feedManager struct {
+	sync.RWMutex
+	m *feeds.Manager
+}
+
+// SetFeedManager configures the feeds.Manager used to resolve
+// denylist-source-feed references. It is expected to be called once,
+// during controller start-up, from wherever the controller constructs
+// its other long-lived, background-refreshed dependencies (alongside the
+// equivalent start-up call to feeds.Manager.Run); that wiring lives
+// outside this annotation-parsing package.
+func SetFeedManager(m *feeds.Manager) {
+	feedManager.Lock()
+	defer feedManager.Unlock()
+	feedManager.m = m
+}
+
+func getFeedManager() *feeds.Manager {
+	feedManager.RLock()
+	defer feedManager.RUnlock()
+	return feedManager.m
+}
+
+// validateFeedNames checks that the annotation is a comma-separated list
+// of lowercase, hyphen-separated feed names.
+func validateFeedNames(val string) error {
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimSpace(name)
+		if !feedNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid denylist feed name %q", name)
+		}
+	}
+	return nil
+}
+
+// SourceRange returns the CIDR, countries and ASNs a location should
+// block traffic from. Warnings carries non-fatal diagnostics about the
+// annotation, such as redundant or mergeable CIDRs, that shouldn't block
+// the Ingress from being admitted.
 type SourceRange struct {
-	CIDR []string `json:"cidr,omitempty"`
+	CIDR      []string `json:"cidr,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+	ASNs      []uint32 `json:"asns,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+
+	// Action is what a matching request should receive. It defaults to
+	// ActionDeny when denylist-action isn't set.
+	Action Action `json:"action,omitempty"`
+	// RateLimitRPS is the requests-per-second limit to apply when Action
+	// is ActionRateLimit, and is unused otherwise.
+	RateLimitRPS float64 `json:"rateLimitRPS,omitempty"`
 }
 
 // Equal tests for equality between two SourceRange types
@@ -60,7 +147,34 @@ func (sr1 *SourceRange) Equal(sr2 *SourceRange) bool {
 		return false
 	}
 
-	return sets.StringElementsMatch(sr1.CIDR, sr2.CIDR)
+	return sets.StringElementsMatch(sr1.CIDR, sr2.CIDR) &&
+		sets.StringElementsMatch(sr1.Countries, sr2.Countries) &&
+		asnElementsMatch(sr1.ASNs, sr2.ASNs) &&
+		sr1.Action == sr2.Action &&
+		sr1.RateLimitRPS == sr2.RateLimitRPS
+}
+
+// asnElementsMatch reports whether a1 and a2 contain the same ASNs,
+// irrespective of order or duplicates.
+func asnElementsMatch(a1, a2 []uint32) bool {
+	if len(a1) != len(a2) {
+		return false
+	}
+
+	counts := make(map[uint32]int, len(a1))
+	for _, a := range a1 {
+		counts[a]++
+	}
+	for _, a := range a2 {
+		counts[a]--
+	}
+	for _, c := range counts {
+		if c != 0 {
+			return false
+		}
+	}
+
+	return true
 }
 
 type ipdenylist struct {
@@ -87,37 +201,190 @@ func (a ipdenylist) Parse(ing *networking.Ingress) (interface{}, error) {
 	copy(defaultDenylistSourceRange, defBackend.DenylistSourceRange)
 	sort.Strings(defaultDenylistSourceRange)
 
+	cidrs := []string{}
+
 	val, err := parser.GetStringAnnotation(ipDenylistAnnotation, ing, a.annotationConfig.Annotations)
-	if err != nil {
-		if err == ing_errors.ErrMissingAnnotations {
-			return &SourceRange{CIDR: defaultDenylistSourceRange}, nil
+	switch {
+	case err == nil:
+		ipnets, ips, err := net.ParseIPNets(strings.Split(val, ",")...)
+		if err != nil && len(ips) == 0 {
+			return &SourceRange{CIDR: defaultDenylistSourceRange}, ing_errors.LocationDenied{
+				Reason: fmt.Errorf("the annotation does not contain a valid IP address or network: %w", err),
+			}
 		}
 
+		for k := range ipnets {
+			cidrs = append(cidrs, k)
+		}
+		for k := range ips {
+			cidrs = append(cidrs, k)
+		}
+	case err == ing_errors.ErrMissingAnnotations:
+		// No denylist-source-range: fall through and still resolve
+		// denylist-source-feed/-country/-asn/-action below, since any of
+		// those may be set on their own.
+		cidrs = append(cidrs, defaultDenylistSourceRange...)
+	default:
 		return &SourceRange{CIDR: defaultDenylistSourceRange}, ing_errors.LocationDenied{
 			Reason: err,
 		}
+	}
 
+	feedCIDRs, err := a.feedCIDRs(ing)
+	if err != nil {
+		return &SourceRange{CIDR: defaultDenylistSourceRange}, err
 	}
 
-	values := strings.Split(val, ",")
-	ipnets, ips, err := net.ParseIPNets(values...)
-	if err != nil && len(ips) == 0 {
+	cidrs = append(cidrs, feedCIDRs...)
+	cidrs = dedupeStrings(cidrs)
+
+	cidrs, warnings, err := net.CollapseIPNets(cidrs)
+	if err != nil {
 		return &SourceRange{CIDR: defaultDenylistSourceRange}, ing_errors.LocationDenied{
-			Reason: fmt.Errorf("the annotation does not contain a valid IP address or network: %w", err),
+			Reason: fmt.Errorf("collapsing denylist CIDRs: %w", err),
 		}
 	}
 
-	cidrs := []string{}
-	for k := range ipnets {
-		cidrs = append(cidrs, k)
+	countries, err := a.parseCountries(ing)
+	if err != nil {
+		return &SourceRange{CIDR: defaultDenylistSourceRange}, err
 	}
-	for k := range ips {
-		cidrs = append(cidrs, k)
+
+	asns, err := a.parseASNs(ing)
+	if err != nil {
+		return &SourceRange{CIDR: defaultDenylistSourceRange}, err
 	}
 
-	sort.Strings(cidrs)
+	action, rateLimitRPS, err := a.parseAction(ing)
+	if err != nil {
+		return &SourceRange{CIDR: defaultDenylistSourceRange}, err
+	}
 
-	return &SourceRange{cidrs}, nil
+	if len(warnings) > 0 {
+		klog.Warningf("Ingress %s/%s: %s", ing.Namespace, ing.Name, strings.Join(warnings, "; "))
+	}
+
+	return &SourceRange{
+		CIDR:         cidrs,
+		Countries:    countries,
+		ASNs:         asns,
+		Warnings:     warnings,
+		Action:       action,
+		RateLimitRPS: rateLimitRPS,
+	}, nil
+}
+
+// parseAction resolves the denylist-action annotation, defaulting to
+// ActionDeny when it is absent to preserve the historical hard-403
+// behavior.
+func (a ipdenylist) parseAction(ing *networking.Ingress) (Action, float64, error) {
+	val, err := parser.GetStringAnnotation(denylistActionAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if err == ing_errors.ErrMissingAnnotations {
+			return ActionDeny, 0, nil
+		}
+		return "", 0, ing_errors.LocationDenied{Reason: err}
+	}
+
+	action, rps, err := parseDenylistAction(val)
+	if err != nil {
+		return "", 0, ing_errors.LocationDenied{Reason: err}
+	}
+
+	return action, rps, nil
+}
+
+// parseCountries resolves the denylist-source-country annotation, if
+// present, into a sorted, deduplicated list of ISO-3166-1 country codes.
+func (a ipdenylist) parseCountries(ing *networking.Ingress) ([]string, error) {
+	val, err := parser.GetStringAnnotation(denylistSourceCountryAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if err == ing_errors.ErrMissingAnnotations {
+			return nil, nil
+		}
+		return nil, ing_errors.LocationDenied{Reason: err}
+	}
+
+	countries := []string{}
+	for _, code := range strings.Split(val, ",") {
+		countries = append(countries, strings.TrimSpace(code))
+	}
+	countries = dedupeStrings(countries)
+	sort.Strings(countries)
+
+	return countries, nil
+}
+
+// parseASNs resolves the denylist-source-asn annotation, if present, into
+// a sorted, deduplicated list of autonomous system numbers.
+func (a ipdenylist) parseASNs(ing *networking.Ingress) ([]uint32, error) {
+	val, err := parser.GetStringAnnotation(denylistSourceASNAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if err == ing_errors.ErrMissingAnnotations {
+			return nil, nil
+		}
+		return nil, ing_errors.LocationDenied{Reason: err}
+	}
+
+	seen := make(map[uint32]struct{})
+	asns := []uint32{}
+	for _, raw := range strings.Split(val, ",") {
+		asn, err := parseASN(raw)
+		if err != nil {
+			return nil, ing_errors.LocationDenied{Reason: err}
+		}
+		if _, ok := seen[asn]; ok {
+			continue
+		}
+		seen[asn] = struct{}{}
+		asns = append(asns, asn)
+	}
+	sort.Slice(asns, func(i, j int) bool { return asns[i] < asns[j] })
+
+	return asns, nil
+}
+
+// feedCIDRs resolves the denylist-source-feed annotation, if present,
+// against the configured feed manager, following the same
+// ErrMissingAnnotations-vs-LocationDenied pattern as parseCountries and
+// parseASNs. A feed name that hasn't been refreshed yet, or isn't
+// configured at all, simply contributes no CIDRs; it's only a malformed
+// annotation value that denies the location.
+func (a ipdenylist) feedCIDRs(ing *networking.Ingress) ([]string, error) {
+	val, err := parser.GetStringAnnotation(denylistSourceFeedAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if err == ing_errors.ErrMissingAnnotations {
+			return nil, nil
+		}
+		return nil, ing_errors.LocationDenied{Reason: err}
+	}
+
+	manager := getFeedManager()
+	if manager == nil {
+		return nil, nil
+	}
+
+	var cidrs []string
+	for _, name := range strings.Split(val, ",") {
+		cidrs = append(cidrs, manager.CIDRs(strings.TrimSpace(name))...)
+	}
+
+	return cidrs, nil
+}
+
+// dedupeStrings returns values with duplicate entries removed, preserving
+// the order of first occurrence.
+func dedupeStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
 }
 
 func (a ipdenylist) GetDocumentation() parser.AnnotationFields {
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/denylist/feeds"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *networking.Ingress {
+	defaultBackend := networking.IngressBackend{
+		Service: &networking.IngressServiceBackend{
+			Name: "default-backend",
+			Port: networking.ServiceBackendPort{
+				Number: 80,
+			},
+		},
+	}
+
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			DefaultBackend: &networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{
+					Name: "default-backend",
+					Port: networking.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+			Rules: []networking.IngressRule{
+				{
+					Host: "foo.bar.com",
+					IngressRuleValue: networking.IngressRuleValue{
+						HTTP: &networking.HTTPIngressRuleValue{
+							Paths: []networking.HTTPIngressPath{
+								{
+									Path:    "/foo",
+									Backend: defaultBackend,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// fakeFeedResolver is a feeds.FeedResolver backed by a plain map, so a
+// feeds.Manager can be populated synchronously in tests.
+type fakeFeedResolver map[string][]string
+
+func (r fakeFeedResolver) Resolve(name string) ([]string, error) {
+	cidrs, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("no feed named %q", name)
+	}
+	return cidrs, nil
+}
+
+// withFeedManager installs a feeds.Manager backed by resolved, seeded
+// with names, for the duration of a test and restores whatever was
+// configured before.
+func withFeedManager(t *testing.T, resolved fakeFeedResolver, names ...string) {
+	t.Helper()
+
+	manager := feeds.NewManager(resolved, time.Hour)
+	stopCh := make(chan struct{})
+	close(stopCh)
+	manager.Run(stopCh, names)
+
+	SetFeedManager(manager)
+	t.Cleanup(func() { SetFeedManager(nil) })
+}
+
+func TestParseFallsThroughToFeedWhenSourceRangeMissing(t *testing.T) {
+	withFeedManager(t, fakeFeedResolver{"threat-intel": {"10.0.0.0/8"}}, "threat-intel")
+
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(denylistSourceFeedAnnotation)] = "threat-intel"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sr := i.(*SourceRange)
+	if !reflect.DeepEqual(sr.CIDR, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected the feed CIDR to be resolved even without denylist-source-range, got %v", sr.CIDR)
+	}
+}
+
+func TestParseFallsThroughToCountryWhenSourceRangeMissing(t *testing.T) {
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(denylistSourceCountryAnnotation)] = "CN,RU"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sr := i.(*SourceRange)
+	if !reflect.DeepEqual(sr.Countries, []string{"CN", "RU"}) {
+		t.Errorf("expected denylist-source-country to be resolved even without denylist-source-range, got %v", sr.Countries)
+	}
+}
+
+func TestParseFallsThroughToActionWhenSourceRangeMissing(t *testing.T) {
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(denylistActionAnnotation)] = "tarpit"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sr := i.(*SourceRange)
+	if sr.Action != ActionTarpit {
+		t.Errorf("expected denylist-action to be resolved even without denylist-source-range, got %v", sr.Action)
+	}
+}
+
+func TestParseWithoutAnyDenylistAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sr := i.(*SourceRange)
+	if len(sr.CIDR) != 0 || len(sr.Countries) != 0 || len(sr.ASNs) != 0 || sr.Action != ActionDeny {
+		t.Errorf("expected an empty, deny-defaulted SourceRange, got %+v", sr)
+	}
+}
+
+func TestParseFeedNameInvalid(t *testing.T) {
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(denylistSourceFeedAnnotation)] = "Not A Valid Feed Name!"
+	ing.SetAnnotations(data)
+
+	if _, err := NewParser(&resolver.Mock{}).Parse(ing); err == nil {
+		t.Error("expected an error for an invalid denylist-source-feed value")
+	}
+}
+
+func TestParseCollapsesAndResolvesFeedCIDRs(t *testing.T) {
+	withFeedManager(t, fakeFeedResolver{"threat-intel": {"10.0.0.0/9", "10.128.0.0/9"}}, "threat-intel")
+
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(denylistSourceFeedAnnotation)] = "threat-intel"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sr := i.(*SourceRange)
+	if !reflect.DeepEqual(sr.CIDR, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected the two /9 feed CIDRs to collapse to 10.0.0.0/8, got %v", sr.CIDR)
+	}
+}
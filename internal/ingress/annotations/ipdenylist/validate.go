@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"fmt"
+	stdnet "net"
+)
+
+// ValidateAgainst checks this SourceRange's CIDRs against admission-time
+// policy. It is meant to be called from the ingress-nginx admission
+// webhook so that conflicting rules are rejected at `kubectl apply` time
+// rather than silently producing an unreachable location block:
+//
+//   - none of the CIDRs may overlap with whitelistCIDRs (the companion
+//     whitelist-source-range annotation's values), since nginx evaluating
+//     allow before deny would otherwise make the location unreachable.
+//   - every CIDR must fall within one of allowedSupernets, the operator's
+//     configured superset of networks a denylist is permitted to target
+//     (e.g. "0.0.0.0/0" minus RFC1918).
+//
+// Either slice may be empty to skip that check.
+func (sr *SourceRange) ValidateAgainst(whitelistCIDRs []string, allowedSupernets []string) error {
+	if sr == nil || len(sr.CIDR) == 0 {
+		return nil
+	}
+
+	denyNets, err := parseCIDRList(sr.CIDR)
+	if err != nil {
+		return err
+	}
+
+	if len(whitelistCIDRs) > 0 {
+		allowNets, err := parseCIDRList(whitelistCIDRs)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range denyNets {
+			for _, w := range allowNets {
+				if netsOverlap(d, w) {
+					return fmt.Errorf("denylist-source-range %s overlaps with whitelist-source-range %s, which would make the location unreachable", d.String(), w.String())
+				}
+			}
+		}
+	}
+
+	if len(allowedSupernets) > 0 {
+		superNets, err := parseCIDRList(allowedSupernets)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range denyNets {
+			if !coveredByAny(d, superNets) {
+				return fmt.Errorf("denylist-source-range %s falls outside the networks this cluster permits blocking", d.String())
+			}
+		}
+	}
+
+	return nil
+}
+
+// netsOverlap reports whether a and b share any address, i.e. either
+// network's address falls inside the other.
+func netsOverlap(a, b *stdnet.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// coveredByAny reports whether n is fully contained within one of supers.
+func coveredByAny(n *stdnet.IPNet, supers []*stdnet.IPNet) bool {
+	nOnes, _ := n.Mask.Size()
+
+	for _, s := range supers {
+		if !s.Contains(n.IP) {
+			continue
+		}
+		sOnes, _ := s.Mask.Size()
+		if sOnes <= nOnes {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCIDRList parses each value as a CIDR, or a bare IP address
+// treated as a single-address /32 or /128 network.
+func parseCIDRList(values []string) ([]*stdnet.IPNet, error) {
+	nets := make([]*stdnet.IPNet, 0, len(values))
+	for _, v := range values {
+		n, err := parseCIDROrIP(v)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func parseCIDROrIP(value string) (*stdnet.IPNet, error) {
+	if _, n, err := stdnet.ParseCIDR(value); err == nil {
+		return n, nil
+	}
+
+	ip := stdnet.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not a valid IP address or network", value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &stdnet.IPNet{IP: ip4, Mask: stdnet.CIDRMask(32, 32)}, nil
+	}
+	return &stdnet.IPNet{IP: ip, Mask: stdnet.CIDRMask(128, 128)}, nil
+}
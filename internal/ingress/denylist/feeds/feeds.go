@@ -0,0 +1,269 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package feeds resolves named threat-intelligence feeds (e.g.
+// "threat-intel", "tor-exit-nodes") referenced by the
+// denylist-source-feed annotation into the CIDRs they currently contain,
+// and keeps those CIDRs fresh in the background so annotation parsing
+// never blocks on a network call.
+package feeds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// FeedResolver resolves a named feed to the list of CIDRs it currently
+// publishes. Implementations are expected to be safe for concurrent use.
+type FeedResolver interface {
+	Resolve(name string) ([]string, error)
+}
+
+// parseFeedBody reads a plain-text, newline-separated list of CIDRs,
+// ignoring blank lines and "#" comments, in the style of the MaxMind and
+// Spamhaus DROP list exports. Lines that aren't a valid CIDR or IP
+// address are dropped and logged rather than returned: a single
+// malformed line from an upstream feed must not poison every Ingress
+// that references it.
+func parseFeedBody(r io.Reader) ([]string, error) {
+	var cidrs []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !isValidCIDROrIP(line) {
+			klog.Warningf("ignoring malformed entry %q in denylist feed", line)
+			continue
+		}
+		cidrs = append(cidrs, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading feed body: %w", err)
+	}
+
+	return cidrs, nil
+}
+
+// isValidCIDROrIP reports whether value parses as a CIDR or a bare IP
+// address.
+func isValidCIDROrIP(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
+}
+
+// cachedFeed holds the last successfully resolved feed contents together
+// with the validator headers needed to make a conditional request next
+// time around.
+type cachedFeed struct {
+	cidrs        []string
+	etag         string
+	lastModified string
+}
+
+// HTTPFeedResolver resolves feeds by fetching a CIDR list from a
+// per-feed-name URL, such as a MaxMind or AbuseIPDB export. Responses are
+// cached and re-validated with ETag/Last-Modified headers so unchanged
+// feeds don't cost a full download on every refresh.
+type HTTPFeedResolver struct {
+	client *http.Client
+	urls   map[string]string
+
+	mu    sync.RWMutex
+	cache map[string]cachedFeed
+}
+
+// NewHTTPFeedResolver creates a resolver that downloads feed contents
+// from the given per-feed-name URLs.
+func NewHTTPFeedResolver(urls map[string]string) *HTTPFeedResolver {
+	return &HTTPFeedResolver{
+		client: &http.Client{Timeout: 15 * time.Second},
+		urls:   urls,
+		cache:  make(map[string]cachedFeed),
+	}
+}
+
+// Resolve implements FeedResolver.
+func (r *HTTPFeedResolver) Resolve(name string) ([]string, error) {
+	url, ok := r.urls[name]
+	if !ok {
+		return nil, fmt.Errorf("no source configured for denylist feed %q", name)
+	}
+
+	r.mu.RLock()
+	cached, hasCached := r.cache[name]
+	r.mu.RUnlock()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request for denylist feed %q: %w", name, err)
+	}
+	if hasCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if hasCached {
+			klog.Warningf("denylist feed %q temporarily unreachable, serving cached CIDRs: %v", name, err)
+			return cached.cidrs, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.cidrs, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if hasCached {
+			klog.Warningf("denylist feed %q returned HTTP %d, serving cached CIDRs", name, resp.StatusCode)
+			return cached.cidrs, nil
+		}
+		return nil, fmt.Errorf("denylist feed %q returned HTTP %d", name, resp.StatusCode)
+	}
+
+	cidrs, err := parseFeedBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing denylist feed %q: %w", name, err)
+	}
+
+	r.mu.Lock()
+	r.cache[name] = cachedFeed{
+		cidrs:        cidrs,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	r.mu.Unlock()
+
+	return cidrs, nil
+}
+
+// ConfigMapGetter returns the ConfigMap data this resolver should read
+// feeds from. It matches the shape of a client-go ConfigMapLister Get so
+// callers can plumb one through without this package importing client-go
+// directly.
+type ConfigMapGetter func(namespace, name string) (map[string]string, error)
+
+// ConfigMapFeedResolver resolves feeds whose CIDR lists are mirrored into
+// a single ConfigMap, one key per feed name, by an external sync job
+// (e.g. a CronJob that pulls the latest Spamhaus DROP export).
+type ConfigMapFeedResolver struct {
+	get             ConfigMapGetter
+	namespace, name string
+}
+
+// NewConfigMapFeedResolver creates a resolver reading feeds from the keys
+// of the ConfigMap namespace/name.
+func NewConfigMapFeedResolver(get ConfigMapGetter, namespace, name string) *ConfigMapFeedResolver {
+	return &ConfigMapFeedResolver{get: get, namespace: namespace, name: name}
+}
+
+// Resolve implements FeedResolver.
+func (r *ConfigMapFeedResolver) Resolve(name string) ([]string, error) {
+	data, err := r.get(r.namespace, r.name)
+	if err != nil {
+		return nil, fmt.Errorf("reading denylist feed ConfigMap %s/%s: %w", r.namespace, r.name, err)
+	}
+
+	raw, ok := data[name]
+	if !ok {
+		return nil, fmt.Errorf("denylist feed %q not present in ConfigMap %s/%s", name, r.namespace, r.name)
+	}
+
+	return parseFeedBody(strings.NewReader(raw))
+}
+
+// Manager keeps a background-refreshed cache of resolved feed CIDRs so
+// that annotation parsing can read them without ever making a network or
+// API server call on the hot path.
+type Manager struct {
+	resolver FeedResolver
+	interval time.Duration
+
+	mu    sync.RWMutex
+	cache map[string][]string
+}
+
+// NewManager creates a Manager that refreshes feeds resolved through
+// resolver every interval.
+func NewManager(resolver FeedResolver, interval time.Duration) *Manager {
+	return &Manager{
+		resolver: resolver,
+		interval: interval,
+		cache:    make(map[string][]string),
+	}
+}
+
+// CIDRs returns the last successfully resolved CIDRs for the named feed,
+// or nil if it has not been resolved yet.
+func (m *Manager) CIDRs(name string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cache[name]
+}
+
+// Run refreshes names immediately and then every interval, until stopCh
+// is closed. It is meant to be run in its own goroutine for the lifetime
+// of the controller.
+func (m *Manager) Run(stopCh <-chan struct{}, names []string) {
+	m.refresh(names)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.refresh(names)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (m *Manager) refresh(names []string) {
+	for _, name := range names {
+		cidrs, err := m.resolver.Resolve(name)
+		if err != nil {
+			klog.Warningf("failed to refresh denylist feed %q, keeping previous CIDRs: %v", name, err)
+			continue
+		}
+
+		m.mu.Lock()
+		m.cache[name] = cidrs
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feeds
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFeedBodyFiltersMalformedLines(t *testing.T) {
+	body := strings.NewReader(strings.Join([]string{
+		"# a comment",
+		"",
+		"10.0.0.0/8",
+		"not-a-cidr",
+		"  192.168.1.1  ",
+		"300.0.0.0/8",
+	}, "\n"))
+
+	cidrs, err := parseFeedBody(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"10.0.0.0/8", "192.168.1.1"}
+	if !reflect.DeepEqual(cidrs, expected) {
+		t.Errorf("expected %v, got %v", expected, cidrs)
+	}
+}
+
+func TestHTTPFeedResolverUnconfiguredFeed(t *testing.T) {
+	r := NewHTTPFeedResolver(map[string]string{})
+	if _, err := r.Resolve("threat-intel"); err == nil {
+		t.Error("expected an error for an unconfigured feed")
+	}
+}
+
+func TestHTTPFeedResolverCaching(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "10.0.0.0/8\n")
+	}))
+	defer srv.Close()
+
+	r := NewHTTPFeedResolver(map[string]string{"threat-intel": srv.URL})
+
+	cidrs, err := r.Resolve("threat-intel")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cidrs, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected [10.0.0.0/8], got %v", cidrs)
+	}
+
+	cidrs, err = r.Resolve("threat-intel")
+	if err != nil {
+		t.Fatalf("unexpected error on second resolve: %v", err)
+	}
+	if !reflect.DeepEqual(cidrs, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected cached [10.0.0.0/8], got %v", cidrs)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests to the feed server, got %d", requests)
+	}
+}
+
+type staticResolver map[string][]string
+
+func (r staticResolver) Resolve(name string) ([]string, error) {
+	cidrs, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("no feed named %q", name)
+	}
+	return cidrs, nil
+}
+
+func TestManagerCIDRsReflectsLastRefresh(t *testing.T) {
+	m := NewManager(staticResolver{"threat-intel": {"10.0.0.0/8"}}, 0)
+
+	if cidrs := m.CIDRs("threat-intel"); cidrs != nil {
+		t.Errorf("expected no CIDRs before the first refresh, got %v", cidrs)
+	}
+
+	m.refresh([]string{"threat-intel", "unknown-feed"})
+
+	if cidrs := m.CIDRs("threat-intel"); !reflect.DeepEqual(cidrs, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected [10.0.0.0/8], got %v", cidrs)
+	}
+	if cidrs := m.CIDRs("unknown-feed"); cidrs != nil {
+		t.Errorf("expected a failed refresh to leave the cache empty, got %v", cidrs)
+	}
+}
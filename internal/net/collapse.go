@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// CollapseIPNets parses each of values as a CIDR or a bare IP address and
+// returns the minimal set of CIDR strings that cover the same address
+// space: networks already covered by a larger prefix are dropped, and
+// adjacent same-length sibling prefixes (e.g. 10.0.0.0/9 and
+// 10.128.0.0/9) are merged into their shared parent. Each dropped or
+// merged input is reported back as a human-readable warning so callers
+// can surface a diagnostic without failing validation. IPv4-mapped IPv6
+// addresses (e.g. ::ffff:10.0.0.0/104) are normalized to plain IPv4
+// before comparison, so they collapse with dotted-quad CIDRs covering
+// the same range.
+func CollapseIPNets(values []string) (collapsed []string, warnings []string, err error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		n, perr := parseIPNetOrIP(v)
+		if perr != nil {
+			return nil, nil, perr
+		}
+		nets = append(nets, n)
+	}
+
+	sort.Slice(nets, func(i, j int) bool { return lessIPNet(nets[i], nets[j]) })
+
+	kept := dropContained(nets, &warnings)
+
+	for {
+		merged, changed, mergeWarnings := mergeSiblings(kept)
+		if !changed {
+			break
+		}
+		// A merge replaces two networks with their (one-bit-shorter)
+		// parent, which can sort ahead of or behind entries that were
+		// adjacent to it before the merge. mergeSiblings only merges
+		// same-length pairs in address order, so the slice must be
+		// back in that order before the next round can find the
+		// parent's own sibling.
+		sort.Slice(merged, func(i, j int) bool { return lessIPNet(merged[i], merged[j]) })
+		kept = merged
+		warnings = append(warnings, mergeWarnings...)
+	}
+
+	out := make([]string, 0, len(kept))
+	for _, n := range kept {
+		out = append(out, n.String())
+	}
+	sort.Strings(out)
+
+	return out, warnings, nil
+}
+
+// parseIPNetOrIP parses value as a CIDR, falling back to a bare IP
+// address treated as a single-address /32 or /128 network.
+func parseIPNetOrIP(value string) (*net.IPNet, error) {
+	if _, ipnet, err := net.ParseCIDR(value); err == nil {
+		return normalizeIPNet(ipnet), nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not a valid IP address or network", value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// normalizeIPNet rewrites an IPv4-mapped IPv6 network as the equivalent
+// plain IPv4 network, so it compares equal to, and can merge with, CIDRs
+// already written in dotted-quad form.
+func normalizeIPNet(n *net.IPNet) *net.IPNet {
+	ip4 := n.IP.To4()
+	if ip4 == nil || len(n.IP) != net.IPv6len {
+		return n
+	}
+
+	ones, bits := n.Mask.Size()
+	if bits != 128 || ones < 96 {
+		return n
+	}
+
+	return &net.IPNet{IP: ip4, Mask: net.CIDRMask(ones-96, 32)}
+}
+
+func family(n *net.IPNet) int {
+	if n.IP.To4() != nil {
+		return 4
+	}
+	return 6
+}
+
+// lessIPNet orders networks by family, then ascending prefix length,
+// then network address, matching the order CollapseIPNets needs to
+// detect containment and adjacency in a single pass.
+func lessIPNet(a, b *net.IPNet) bool {
+	if fa, fb := family(a), family(b); fa != fb {
+		return fa < fb
+	}
+
+	aOnes, _ := a.Mask.Size()
+	bOnes, _ := b.Mask.Size()
+	if aOnes != bOnes {
+		return aOnes < bOnes
+	}
+
+	return compareIP(a.IP, b.IP) < 0
+}
+
+func compareIP(a, b net.IP) int {
+	if a4, b4 := a.To4(), b.To4(); a4 != nil && b4 != nil {
+		a, b = a4, b4
+	}
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// dropContained removes any network already covered by an earlier,
+// equal-or-larger prefix of the same family. sorted must already be
+// ordered by lessIPNet.
+func dropContained(sorted []*net.IPNet, warnings *[]string) []*net.IPNet {
+	kept := make([]*net.IPNet, 0, len(sorted))
+	for _, n := range sorted {
+		redundant := false
+		for _, k := range kept {
+			if family(k) != family(n) {
+				continue
+			}
+			if k.Contains(n.IP) {
+				redundant = true
+				*warnings = append(*warnings, fmt.Sprintf("%s is redundant, already covered by %s", n.String(), k.String()))
+				break
+			}
+		}
+		if !redundant {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// mergeSiblings does a single left-to-right pass merging adjacent,
+// same-length sibling prefixes into their shared parent. Callers should
+// keep calling it until changed is false, since merging a pair can
+// itself produce a new mergeable pair one level up.
+func mergeSiblings(nets []*net.IPNet) (merged []*net.IPNet, changed bool, warnings []string) {
+	out := make([]*net.IPNet, 0, len(nets))
+	for i := 0; i < len(nets); i++ {
+		if i+1 < len(nets) {
+			if parent, ok := mergePair(nets[i], nets[i+1]); ok {
+				out = append(out, parent)
+				warnings = append(warnings, fmt.Sprintf("%s and %s merged into %s", nets[i].String(), nets[i+1].String(), parent.String()))
+				changed = true
+				i++
+				continue
+			}
+		}
+		out = append(out, nets[i])
+	}
+	return out, changed, warnings
+}
+
+// mergePair returns the parent network of a and b if they are the two
+// halves of a single, one-bit-wider prefix.
+func mergePair(a, b *net.IPNet) (*net.IPNet, bool) {
+	if family(a) != family(b) {
+		return nil, false
+	}
+
+	aOnes, bits := a.Mask.Size()
+	bOnes, _ := b.Mask.Size()
+	if aOnes != bOnes || aOnes == 0 {
+		return nil, false
+	}
+
+	parentMask := net.CIDRMask(aOnes-1, bits)
+	parent := a.IP.Mask(parentMask)
+
+	if !a.IP.Equal(parent) || !b.IP.Mask(parentMask).Equal(parent) {
+		return nil, false
+	}
+
+	return &net.IPNet{IP: parent, Mask: parentMask}, true
+}
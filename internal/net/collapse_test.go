@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollapseIPNets(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		collapsed []string
+	}{
+		{
+			name:      "single network is left alone",
+			values:    []string{"10.0.0.0/8"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "redundant sub-network is dropped",
+			values:    []string{"10.0.0.0/8", "10.1.0.0/16"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "adjacent siblings merge",
+			values:    []string{"10.0.0.0/9", "10.128.0.0/9"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "merging cascades up multiple levels",
+			values:    []string{"10.0.0.0/10", "10.64.0.0/10", "10.128.0.0/9"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "siblings given in reverse order still merge",
+			values:    []string{"10.128.0.0/9", "10.0.0.0/9"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "non-adjacent same-length networks are kept apart",
+			values:    []string{"10.0.0.0/9", "192.168.128.0/9"},
+			collapsed: []string{"10.0.0.0/9", "192.128.0.0/9"},
+		},
+		{
+			name:      "bare IP addresses become host networks",
+			values:    []string{"10.0.0.1", "10.0.0.0/8"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "IPv4-mapped IPv6 normalizes to plain IPv4",
+			values:    []string{"::ffff:10.0.0.0/104", "10.0.0.0/8"},
+			collapsed: []string{"10.0.0.0/8"},
+		},
+		{
+			name:      "IPv6 networks collapse the same way",
+			values:    []string{"2001:db8::/33", "2001:db8:8000::/33"},
+			collapsed: []string{"2001:db8::/32"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collapsed, _, err := CollapseIPNets(tt.values)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(collapsed, tt.collapsed) {
+				t.Errorf("expected %v, got %v", tt.collapsed, collapsed)
+			}
+		})
+	}
+}
+
+func TestCollapseIPNetsWarnings(t *testing.T) {
+	collapsed, warnings, err := CollapseIPNets([]string{"10.0.0.0/9", "10.128.0.0/9", "10.1.0.0/16"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(collapsed, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected collapsing to 10.0.0.0/8, got %v", collapsed)
+	}
+	if len(warnings) == 0 {
+		t.Errorf("expected at least one diagnostic warning about the redundant/merged input")
+	}
+}
+
+func TestCollapseIPNetsInvalidInput(t *testing.T) {
+	if _, _, err := CollapseIPNets([]string{"not-a-cidr"}); err == nil {
+		t.Errorf("expected an error for an invalid CIDR")
+	}
+}